@@ -0,0 +1,305 @@
+package sexp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Comment is a single `;`-line or `#|...|#`-block comment, retained as a
+// first-class Atom so that callers that care about source formatting
+// don't have to re-derive it from raw text.
+type Comment struct {
+	Text  string // comment text, including the leading ";" or "#|"/"|#"
+	Block bool   // true for a #|...|# block comment, false for a ;-line comment
+	start, end Position
+}
+
+func (c *Comment) IsLeaf() bool               { return true }
+func (c *Comment) LeafCount() int             { return 1 }
+func (c *Comment) Head() Sexp                 { return c }
+func (c *Comment) Tail() Sexp                 { return nil }
+func (c *Comment) Format(f fmt.State, r rune) { fmt.Fprint(f, c.Text) }
+func (c *Comment) IsAtom() bool               { return true }
+func (c *Comment) Pos() Position              { return c.start }
+func (c *Comment) End() Position              { return c.end }
+
+// CommentGroup represents a sequence of comments with no blank line and no
+// other tokens between them, analogous to go/ast.CommentGroup.
+type CommentGroup struct {
+	List []*Comment
+}
+
+func (g *CommentGroup) Pos() Position { return g.List[0].Pos() }
+func (g *CommentGroup) End() Position { return g.List[len(g.List)-1].End() }
+
+// Text returns the comment text, stripped of the comment markers and
+// joined with newlines.
+func (g *CommentGroup) Text() string {
+	var buf bytes.Buffer
+	for i, c := range g.List {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		switch {
+		case c.Block:
+			buf.WriteString(c.Text[2 : len(c.Text)-2])
+		default:
+			buf.WriteString(c.Text[1:])
+		}
+	}
+	return buf.String()
+}
+
+// scanComments scans src for `;`-line and `#|...|#`-block comments and
+// groups consecutive ones (no blank line and no other token between them)
+// together. Offsets within src are resolved to Positions through f, the
+// File src was registered under. The scan is separate from parsing
+// because ParseFile's parser treats comments as insignificant whitespace
+// and skips them.
+func scanComments(f *File, src []byte) []*CommentGroup {
+	var groups []*CommentGroup
+	var cur []*Comment
+	lastLine := -1
+
+	flush := func() {
+		if len(cur) > 0 {
+			groups = append(groups, &CommentGroup{List: cur})
+			cur = nil
+		}
+	}
+
+	line := 1
+	for i := 0; i < len(src); {
+		switch {
+		case src[i] == '\n':
+			line++
+			i++
+			continue
+		case src[i] == ';':
+			start := i
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			c := &Comment{
+				Text:  string(src[start:i]),
+				start: f.Position(start),
+				end:   f.Position(i),
+			}
+			if lastLine != -1 && line-lastLine > 1 {
+				flush()
+			}
+			cur = append(cur, c)
+			lastLine = line
+		case src[i] == '#' && i+1 < len(src) && src[i+1] == '|':
+			start := i
+			i += 2
+			for i+1 < len(src) && !(src[i] == '|' && src[i+1] == '#') {
+				if src[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+			if i > len(src) {
+				i = len(src)
+			}
+			c := &Comment{
+				Text:  string(src[start:i]),
+				Block: true,
+				start: f.Position(start),
+				end:   f.Position(i),
+			}
+			if lastLine != -1 && line-lastLine > 1 {
+				flush()
+			}
+			cur = append(cur, c)
+			lastLine = line
+		default:
+			if !isSpaceByte(src[i]) {
+				flush()
+			}
+			i++
+		}
+	}
+	flush()
+	return groups
+}
+
+// CommentMap associates comment groups with the Sexp nodes they document,
+// analogous to go/ast.CommentMap. Association requires position-tracked
+// nodes, i.e. the result of ParseFile/ParseComments.
+type CommentMap map[Sexp][]*CommentGroup
+
+// ParseComments parses the content read from r exactly like ParseFile,
+// additionally scanning it for comments and associating each comment
+// group with the nearest node, favouring trailing association on the
+// same line and leading association otherwise.
+func ParseComments(fset *FileSet, name string, r io.Reader) ([]Sexp, CommentMap, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sexps, err := ParseFile(fset, name, bytes.NewReader(src))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var f *File
+	for _, candidate := range fset.files {
+		if candidate.Name() == name {
+			f = candidate
+		}
+	}
+	groups := scanComments(f, src)
+
+	cmap := NewCommentMap(sexps, groups)
+	return sexps, cmap, nil
+}
+
+// NewCommentMap associates each comment group in comments with the
+// nearest node among nodes, using source position: a group starting on
+// the same line a node ends is attached as a trailing comment of that
+// node, otherwise it leads the first node whose start line is >= the
+// group's end line, falling back to the last node if there is none.
+//
+// Only the top-level Sexps ParseFile returns carry positions (their
+// children don't), so despite the name "nearest node", association is
+// top-level-granular: a comment inside a List attaches to the enclosing
+// top-level form, not to the specific child it sits next to.
+func NewCommentMap(nodes []Sexp, comments []*CommentGroup) CommentMap {
+	cmap := CommentMap{}
+
+	var positioned []Positioned
+	for _, n := range nodes {
+		Inspect(n, func(s Sexp) bool {
+			if s == nil {
+				return false
+			}
+			if p, ok := s.(Positioned); ok {
+				positioned = append(positioned, p)
+			}
+			return true
+		})
+	}
+
+	for _, g := range comments {
+		var attachTo Sexp
+
+		for _, p := range positioned {
+			if p.End().Line == g.Pos().Line {
+				attachTo = p.(Sexp)
+			}
+		}
+
+		if attachTo == nil {
+			for _, p := range positioned {
+				if p.Pos().Line >= g.End().Line {
+					attachTo = p.(Sexp)
+					break
+				}
+			}
+		}
+
+		if attachTo == nil && len(positioned) > 0 {
+			attachTo = positioned[len(positioned)-1].(Sexp)
+		}
+
+		if attachTo != nil {
+			cmap[attachTo] = append(cmap[attachTo], g)
+		}
+	}
+	return cmap
+}
+
+// Filter returns a new CommentMap restricted to comment groups attached
+// to node or one of its descendants.
+func (cmap CommentMap) Filter(node Sexp) CommentMap {
+	keep := map[Sexp]bool{}
+	Inspect(node, func(s Sexp) bool {
+		// A List is a slice, so it cannot be a map key and never has
+		// comments attached.
+		if _, isList := s.(List); s != nil && !isList {
+			keep[s] = true
+		}
+		return true
+	})
+
+	filtered := CommentMap{}
+	for n, groups := range cmap {
+		if keep[n] {
+			filtered[n] = groups
+		}
+	}
+	return filtered
+}
+
+// Comments returns all comment groups in the map, in no particular order.
+func (cmap CommentMap) Comments() []*CommentGroup {
+	var groups []*CommentGroup
+	for _, gs := range cmap {
+		groups = append(groups, gs...)
+	}
+	return groups
+}
+
+// Format re-emits node, writing any comment groups attached to it (or its
+// descendants) immediately before the node they are associated with, so
+// that parsing the output and re-running ParseComments recovers the same
+// associations.
+func (cmap CommentMap) Format(w io.Writer, node Sexp) error {
+	var groups []*CommentGroup
+	if _, isList := node.(List); !isList {
+		// A List is a slice and cannot be a map key, so it has no entry.
+		groups = cmap[node]
+	}
+	for _, g := range groups {
+		for _, c := range g.List {
+			// The raw text, markers included, so the output reparses.
+			if _, err := fmt.Fprintln(w, c.Text); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	case List:
+		fmt.Fprint(w, "(")
+		for i, child := range n {
+			if i > 0 {
+				fmt.Fprint(w, " ")
+			}
+			if err := cmap.Format(w, child); err != nil {
+				return err
+			}
+		}
+		fmt.Fprint(w, ")")
+		return nil
+	case *Strict:
+		fmt.Fprint(w, "(")
+		if err := cmap.Format(w, n.Sexp); err != nil {
+			return err
+		}
+		if n.child != nil {
+			fmt.Fprint(w, " ")
+			if err := cmap.Format(w, n.child); err != nil {
+				return err
+			}
+		}
+		fmt.Fprint(w, ")")
+		return nil
+	default:
+		// A position-tracked node (ParseFile's output) carries the
+		// comments attached to it above but, like Walk, is otherwise
+		// transparent: recurse into the wrapped Sexp so its own List/
+		// *Strict structure still gets formatted, instead of falling
+		// to the single-line %s below and losing any comments nested
+		// inside it.
+		if u, ok := node.(interface{ Unwrap() Sexp }); ok {
+			return cmap.Format(w, u.Unwrap())
+		}
+		_, err := fmt.Fprintf(w, "%s", node)
+		return err
+	}
+}
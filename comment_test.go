@@ -0,0 +1,85 @@
+package sexp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestScanCommentsGroupsAdjacentLines(t *testing.T) {
+	fset := NewFileSet()
+	f := fset.AddFile("x.sexp", 0)
+	src := []byte("; line one\n; line two\n\n; separate group\n(f x)\n")
+	f.content = src
+
+	groups := scanComments(f, src)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 comment groups, got %d: %+v", len(groups), groups)
+	}
+	if len(groups[0].List) != 2 {
+		t.Fatalf("expected first group to have 2 adjacent comments, got %d", len(groups[0].List))
+	}
+	if len(groups[1].List) != 1 {
+		t.Fatalf("expected second group to have 1 comment, got %d", len(groups[1].List))
+	}
+}
+
+func TestScanCommentsBlockComment(t *testing.T) {
+	fset := NewFileSet()
+	f := fset.AddFile("x.sexp", 0)
+	src := []byte("#| a block\ncomment |# (f x)\n")
+	f.content = src
+
+	groups := scanComments(f, src)
+	if len(groups) != 1 || !groups[0].List[0].Block {
+		t.Fatalf("expected 1 block comment group, got %+v", groups)
+	}
+}
+
+func TestNewCommentMapAttachesTrailingAndLeading(t *testing.T) {
+	fset := NewFileSet()
+	src := "(a) ; trailing for a\n\n; leading for b\n(b)\n"
+
+	sexps, cmap, err := ParseComments(fset, "x.sexp", bytes.NewBufferString(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sexps) != 2 {
+		t.Fatalf("expected 2 top-level forms, got %d", len(sexps))
+	}
+
+	trailing := cmap[sexps[0]]
+	if len(trailing) != 1 || trailing[0].Text() != " trailing for a" {
+		t.Fatalf("expected a trailing comment on sexps[0], got %+v", trailing)
+	}
+
+	leading := cmap[sexps[1]]
+	if len(leading) != 1 || leading[0].Text() != " leading for b" {
+		t.Fatalf("expected a leading comment on sexps[1], got %+v", leading)
+	}
+}
+
+// commentTestWrapper stands in for the *positioned type ParseFile
+// returns, without depending on position.go internals.
+type commentTestWrapper struct{ Sexp }
+
+func (w commentTestWrapper) Unwrap() Sexp { return w.Sexp }
+
+func TestCommentMapFormatUnwrapsAndHandlesStrict(t *testing.T) {
+	strict := &Strict{Sexp: Symbol("a"), child: Symbol("b")}
+	wrapped := commentTestWrapper{strict}
+
+	cmap := CommentMap{wrapped: []*CommentGroup{{List: []*Comment{{Text: "; hi"}}}}}
+
+	var buf bytes.Buffer
+	if err := cmap.Format(&buf, wrapped); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !bytes.Contains([]byte(got), []byte("hi")) {
+		t.Fatalf("expected comment text in output, got %q", got)
+	}
+	if !bytes.Contains([]byte(got), []byte("(a b)")) {
+		t.Fatalf("expected *Strict to be formatted rather than dropped, got %q", got)
+	}
+}
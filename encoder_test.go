@@ -0,0 +1,137 @@
+package sexp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestMarshalUnmarshalList(t *testing.T) {
+	in := List{Symbol("f"), Symbol("ab"), List{Symbol("g")}}
+
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "(1:f2:ab(1:g))"; string(data) != want {
+		t.Fatalf("got %q want %q", data, want)
+	}
+
+	out, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := out.(List)
+	if !ok || len(list) != 3 {
+		t.Fatalf("bad roundtrip: %#v", out)
+	}
+}
+
+func TestMarshalStrictDoesNotCollideWithList(t *testing.T) {
+	list := List{Symbol("a"), Symbol("b")}
+	strict := &Strict{Sexp: Symbol("a"), child: Symbol("b")}
+
+	listData, err := Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strictData, err := Marshal(strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(listData) == string(strictData) {
+		t.Fatalf("List and *Strict encoded identically: %q", listData)
+	}
+
+	out, err := Unmarshal(strictData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := out.(*Strict)
+	if !ok {
+		t.Fatalf("expected *Strict, got %T", out)
+	}
+	if got.Sexp != Symbol("a") || got.child != Symbol("b") {
+		t.Fatalf("bad roundtrip: %#v", got)
+	}
+}
+
+func TestDecoderCanonicalStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf).SetMode(Canonical)
+	if err := enc.Encode(List{Symbol("a")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(List{Symbol("b"), Symbol("c")}); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(&buf).SetMode(Canonical)
+
+	first, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, ok := first.(List); !ok || len(l) != 1 {
+		t.Fatalf("unexpected first value: %#v", first)
+	}
+
+	second, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("second Decode lost bytes buffered by the first call: %v", err)
+	}
+	if l, ok := second.(List); !ok || len(l) != 2 {
+		t.Fatalf("unexpected second value: %#v", second)
+	}
+}
+
+func TestDecoderBase64TransportStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf).SetMode(Base64Transport)
+	if err := enc.Encode(List{Symbol("a")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(List{Symbol("b")}); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(&buf).SetMode(Base64Transport)
+	for i := 0; i < 2; i++ {
+		if _, err := dec.Decode(); err != nil {
+			t.Fatalf("Decode %d: %v", i, err)
+		}
+	}
+}
+
+func TestDecoderAdvancedStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode(List{Symbol("a"), Symbol("b")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(Symbol("c")); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := NewDecoder(&buf)
+
+	first, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, ok := first.(List); !ok || len(l) != 2 {
+		t.Fatalf("unexpected first value: %#v", first)
+	}
+
+	second, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != Symbol("c") {
+		t.Fatalf("unexpected second value: %#v", second)
+	}
+
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected io.EOF once the stream is exhausted, got %v", err)
+	}
+}
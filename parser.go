@@ -0,0 +1,348 @@
+package sexp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Parser reads s-expressions from a stream and emits them one at a time
+// on Output. Run does the blocking scan-and-parse work; callers drain
+// Output (typically from another goroutine) and check Error once Output
+// has been closed.
+//
+// By default, ";"-line and "#|...|#"-block comments are treated as
+// insignificant whitespace and discarded, like any other Lisp reader.
+// Call RetainComments to have them come back as first-class *Comment
+// atoms instead, interleaved with the surrounding List/top-level
+// elements in the order they occur.
+type Parser struct {
+	Output chan Sexp
+
+	r              *bufio.Reader
+	strict         bool
+	retainComments bool
+	err            error
+
+	offset, line, col int
+}
+
+// NewParser returns a Parser that reads s-expressions from r. If strict
+// is true, every parsed list is converted to its *Strict (cons-chain)
+// form before being sent to Output.
+func NewParser(r io.Reader, strict bool) *Parser {
+	return &Parser{
+		Output: make(chan Sexp),
+		r:      bufio.NewReader(r),
+		strict: strict,
+		line:   1,
+		col:    1,
+	}
+}
+
+// RetainComments switches p into comment-preserving mode and returns p
+// for chaining, mirroring Encoder.SetMode.
+func (p *Parser) RetainComments() *Parser {
+	p.retainComments = true
+	return p
+}
+
+// Error returns the error that stopped parsing, if Run stopped early. It
+// is only meaningful once Output has been closed.
+func (p *Parser) Error() error {
+	return p.err
+}
+
+// Run scans and parses forms from the underlying reader until EOF,
+// sending each top-level form (or, in RetainComments mode, each
+// top-level comment) to Output in the order encountered, then closes
+// Output.
+func (p *Parser) Run() {
+	defer close(p.Output)
+
+	for {
+		c, b, ok, err := p.nextSignificant()
+		if err != nil {
+			p.err = err
+			return
+		}
+		if !ok {
+			return
+		}
+		if c != nil {
+			if p.retainComments {
+				p.Output <- c
+			}
+			continue
+		}
+		if b == ')' {
+			p.err = fmt.Errorf("sexp: unexpected %q", b)
+			return
+		}
+
+		s, err := p.parseExpr(b)
+		if err != nil {
+			p.err = err
+			return
+		}
+		if p.strict {
+			s = toStrict(s)
+		} else {
+			s = collapseSingleton(s)
+		}
+		p.Output <- s
+	}
+}
+
+// parseExpr parses a single expression whose first byte, b, has already
+// been peeked (and not yet consumed) by the caller.
+func (p *Parser) parseExpr(b byte) (Sexp, error) {
+	switch b {
+	case '(':
+		return p.parseList()
+	case ')':
+		return nil, fmt.Errorf("sexp: unexpected %q", b)
+	default:
+		return p.parseSymbol()
+	}
+}
+
+// parseList parses a parenthesized form into its raw List representation,
+// one element per sub-expression (and, in RetainComments mode, interleaved
+// *Comment atoms). Callers collapse or chain this raw form afterwards: see
+// collapseSingleton and toStrict.
+func (p *Parser) parseList() (Sexp, error) {
+	p.readByte() // consume '('
+
+	var elems []Sexp
+	for {
+		c, b, ok, err := p.nextSignificant()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if c != nil {
+			if p.retainComments {
+				elems = append(elems, c)
+			}
+			continue
+		}
+		if b == ')' {
+			p.readByte()
+			break
+		}
+
+		elem, err := p.parseExpr(b)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+
+	return List(elems), nil
+}
+
+func (p *Parser) parseSymbol() (Sexp, error) {
+	var buf []byte
+	for {
+		b, ok := p.peekByte()
+		if !ok || isSpace(b) || b == '(' || b == ')' || b == ';' || p.atBlockCommentStart() {
+			break
+		}
+		p.readByte()
+		buf = append(buf, b)
+	}
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("sexp: expected an expression")
+	}
+	return Symbol(buf), nil
+}
+
+// collapseSingleton recursively reduces every parenthesized form containing
+// exactly one sub-expression to that sub-expression directly: the grouping
+// parens are redundant and don't themselves add structure. This is the
+// non-strict reading of a parsed form.
+func collapseSingleton(s Sexp) Sexp {
+	list, ok := s.(List)
+	if !ok || len(list) == 0 {
+		return s
+	}
+	collapsed := make(List, len(list))
+	for i, e := range list {
+		collapsed[i] = collapseSingleton(e)
+	}
+	if len(collapsed) == 1 {
+		return collapsed[0]
+	}
+	return collapsed
+}
+
+// toStrict converts a parsed List into its cons-chain *Strict form. It
+// works from the raw (uncollapsed) parse: a list whose head is itself a
+// list is flattened — the head list's own elements are spliced in ahead of
+// the rest — so the chain threads through every atom of the original tree
+// rather than boxing each nested list as an opaque link. Otherwise the
+// list's head becomes a *Strict pair of that atom and the chained
+// remainder, except when only one element remains: a remaining atom is
+// the chain's bare tail (matching the untagged cons-chain tail of classic
+// Lisp notation), while a remaining list still chains, since it came from
+// its own parenthesized form in the source and keeps its own nesting.
+func toStrict(s Sexp) Sexp {
+	list, ok := s.(List)
+	if !ok {
+		return s
+	}
+	return toStrictList(list)
+}
+
+func toStrictList(elems List) Sexp {
+	if len(elems) == 0 {
+		return elems
+	}
+	head := elems[0]
+	rest := elems[1:]
+	if headList, ok := head.(List); ok {
+		merged := make(List, 0, len(headList)+len(rest))
+		merged = append(merged, headList...)
+		merged = append(merged, rest...)
+		return toStrictList(merged)
+	}
+	switch {
+	case len(rest) == 0:
+		return &Strict{Sexp: head}
+	case len(rest) == 1:
+		if tailList, ok := rest[0].(List); ok {
+			return &Strict{Sexp: head, child: toStrictList(tailList)}
+		}
+		return &Strict{Sexp: head, child: rest[0]}
+	default:
+		return &Strict{Sexp: head, child: toStrictList(rest)}
+	}
+}
+
+// nextSignificant skips whitespace and reports the next significant
+// byte, which may instead be a fully-scanned comment: comments are
+// always scanned past (never left half-consumed for the caller), but
+// whether one is returned for emission is the caller's decision based on
+// retainComments. ok is false only at EOF.
+func (p *Parser) nextSignificant() (comment *Comment, b byte, ok bool, err error) {
+	for {
+		p.skipSpace()
+		bb, peekOk := p.peekByte()
+		if !peekOk {
+			return nil, 0, false, nil
+		}
+		if bb == ';' {
+			c, cerr := p.scanLineComment()
+			if cerr != nil {
+				return nil, 0, false, cerr
+			}
+			return c, 0, true, nil
+		}
+		if p.atBlockCommentStart() {
+			c, cerr := p.scanBlockComment()
+			if cerr != nil {
+				return nil, 0, false, cerr
+			}
+			return c, 0, true, nil
+		}
+		return nil, bb, true, nil
+	}
+}
+
+func (p *Parser) atBlockCommentStart() bool {
+	b1, b2, ok := p.peek2()
+	return ok && b1 == '#' && b2 == '|'
+}
+
+func (p *Parser) scanLineComment() (*Comment, error) {
+	start := p.position()
+	var buf []byte
+	for {
+		b, ok := p.peekByte()
+		if !ok || b == '\n' {
+			break
+		}
+		p.readByte()
+		buf = append(buf, b)
+	}
+	return &Comment{Text: string(buf), start: start, end: p.position()}, nil
+}
+
+func (p *Parser) scanBlockComment() (*Comment, error) {
+	start := p.position()
+	buf := []byte{'#', '|'}
+	p.readByte()
+	p.readByte()
+
+	for {
+		if b1, b2, ok := p.peek2(); ok {
+			if b1 == '|' && b2 == '#' {
+				p.readByte()
+				p.readByte()
+				buf = append(buf, '|', '#')
+				return &Comment{Text: string(buf), Block: true, start: start, end: p.position()}, nil
+			}
+			p.readByte()
+			buf = append(buf, b1)
+			continue
+		}
+		b, ok := p.peekByte()
+		if !ok {
+			return nil, fmt.Errorf("sexp: unterminated block comment starting at %s", start)
+		}
+		p.readByte()
+		buf = append(buf, b)
+	}
+}
+
+func (p *Parser) skipSpace() {
+	for {
+		b, ok := p.peekByte()
+		if !ok || !isSpace(b) {
+			return
+		}
+		p.readByte()
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func (p *Parser) peekByte() (byte, bool) {
+	bs, err := p.r.Peek(1)
+	if err != nil {
+		return 0, false
+	}
+	return bs[0], true
+}
+
+func (p *Parser) peek2() (byte, byte, bool) {
+	bs, err := p.r.Peek(2)
+	if err != nil {
+		return 0, 0, false
+	}
+	return bs[0], bs[1], true
+}
+
+func (p *Parser) readByte() (byte, bool) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, false
+	}
+	p.offset++
+	if b == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return b, true
+}
+
+func (p *Parser) position() Position {
+	return Position{Offset: p.offset, Line: p.line, Column: p.col}
+}
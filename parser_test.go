@@ -124,6 +124,78 @@ func TestParseStrict(t *testing.T) {
 	}
 }
 
+func TestParserRetainComments(t *testing.T) {
+	src := "; leading\n(f x) ; trailing\n(g y)\n"
+
+	p := NewParser(strings.NewReader(src), false).RetainComments()
+
+	done := make(chan struct{})
+	var sexps []Sexp
+	go func() {
+		for s := range p.Output {
+			sexps = append(sexps, s)
+		}
+		close(done)
+	}()
+	p.Run()
+	<-done
+
+	if p.Error() != nil {
+		t.Fatal(p.Error())
+	}
+
+	if len(sexps) != 4 {
+		t.Fatalf("expected 4 items (2 comments, 2 forms), got %d: %v", len(sexps), sexps)
+	}
+
+	c, ok := sexps[0].(*Comment)
+	if !ok || c.Text != "; leading" {
+		t.Fatalf("expected sexps[0] to be the leading comment, got %#v", sexps[0])
+	}
+	if _, ok := sexps[1].(List); !ok {
+		t.Fatalf("expected sexps[1] to be the (f x) list, got %#v", sexps[1])
+	}
+	c, ok = sexps[2].(*Comment)
+	if !ok || c.Text != "; trailing" {
+		t.Fatalf("expected sexps[2] to be the trailing comment, got %#v", sexps[2])
+	}
+	if _, ok := sexps[3].(List); !ok {
+		t.Fatalf("expected sexps[3] to be the (g y) list, got %#v", sexps[3])
+	}
+}
+
+func TestParserRetainCommentsInsideList(t *testing.T) {
+	src := "(f ; inline\n x)\n"
+
+	p := NewParser(strings.NewReader(src), false).RetainComments()
+
+	done := make(chan struct{})
+	var sexps []Sexp
+	go func() {
+		for s := range p.Output {
+			sexps = append(sexps, s)
+		}
+		close(done)
+	}()
+	p.Run()
+	<-done
+
+	if p.Error() != nil {
+		t.Fatal(p.Error())
+	}
+	if len(sexps) != 1 {
+		t.Fatalf("expected 1 top-level form, got %d: %v", len(sexps), sexps)
+	}
+
+	list, ok := sexps[0].(List)
+	if !ok || len(list) != 3 {
+		t.Fatalf("expected a 3-element list (f, comment, x), got %#v", sexps[0])
+	}
+	if _, ok := list[1].(*Comment); !ok {
+		t.Fatalf("expected the inline comment to appear in place, got %#v", list[1])
+	}
+}
+
 func TestParseString(t *testing.T) {
 	var buf bytes.Buffer
 	for i, ex := range examples {
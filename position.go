@@ -0,0 +1,276 @@
+package sexp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Position describes an arbitrary source position including the file,
+// line, and column location. A Position is valid if the line number is > 0.
+type Position struct {
+	Filename string // filename, if any
+	Offset   int    // offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number, starting at 1 (byte count)
+}
+
+// IsValid reports whether the position is valid.
+func (pos *Position) IsValid() bool { return pos.Line > 0 }
+
+// String returns a string in one of several forms:
+//
+//	file:line:column    valid position with file name
+//	line:column         valid position without file name
+//	file                invalid position with file name
+//	-                   invalid position without file name
+func (pos Position) String() string {
+	s := pos.Filename
+	if pos.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Positioned is implemented by Sexp nodes that carry source position
+// information, typically because they were returned by ParseFile.
+type Positioned interface {
+	Pos() Position // position of first character belonging to the node
+	End() Position // position of first character immediately after the node
+}
+
+// A File holds the source of a single parsed input and converts offsets
+// within it to Positions. Files are created and owned by a FileSet.
+type File struct {
+	name    string
+	base    int    // offset of the first byte of this file within the FileSet
+	size    int    // length of the file content, in bytes
+	content []byte // full source content, set once by ParseFile
+}
+
+// Name returns the file name as registered with AddFile.
+func (f *File) Name() string { return f.name }
+
+// Base returns the base offset of file f, i.e. the offset of its first
+// byte within its FileSet.
+func (f *File) Base() int { return f.base }
+
+// Size returns the size of file f, as registered with AddFile.
+func (f *File) Size() int { return f.size }
+
+// FileSet represents a set of source files. It assigns each registered
+// file a disjoint span of offsets so that offsets across every file in
+// the set are unique, mirroring go/token.FileSet.
+type FileSet struct {
+	base  int
+	files []*File
+}
+
+// NewFileSet creates a new, empty FileSet.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// AddFile registers a new file of the given name and size with the
+// FileSet and returns it. Subsequent offsets recorded against the
+// returned File must lie within [0, size].
+func (s *FileSet) AddFile(name string, size int) *File {
+	f := &File{name: name, base: s.base, size: size}
+	s.base += size + 1
+	s.files = append(s.files, f)
+	return f
+}
+
+// Position converts a File-relative offset into a Position.
+func (f *File) Position(offset int) Position {
+	line, col := f.lineCol(offset)
+	return Position{
+		Filename: f.name,
+		Offset:   offset,
+		Line:     line,
+		Column:   col,
+	}
+}
+
+// lineCol recomputes the line and column for offset by scanning the
+// recorded newlines. Files produced by this package are small enough
+// (s-expression source, not generated binaries) that this is cheap and
+// keeps File free of incremental bookkeeping.
+func (f *File) lineCol(offset int) (line, col int) {
+	line, col = 1, 1
+	for i := 0; i < offset && i < len(f.content); i++ {
+		if f.content[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return
+}
+
+// positioned wraps a Sexp with its start and end Position, implementing
+// both Sexp (by embedding) and Positioned.
+type positioned struct {
+	Sexp
+	start, end Position
+}
+
+func (p *positioned) Pos() Position { return p.start }
+func (p *positioned) End() Position { return p.end }
+
+// Unwrap returns the Sexp that p wraps, letting Walk see through the
+// position wrapper to the underlying tree.
+func (p *positioned) Unwrap() Sexp { return p.Sexp }
+
+// ParseFile parses the content read from r, registers it with fset under
+// name, and returns the resulting Sexps wrapped so that each one
+// satisfies Positioned. It mirrors go/parser.ParseFile.
+//
+// Positions are not derived from how many bytes the Parser happened to
+// have consumed off r by the time it emitted a value on Output: a
+// buffered or look-ahead scanner can read arbitrarily far past the
+// first expression before ever emitting it, which would collapse every
+// expression's span to the same wrong trailing offset. Instead, the
+// full source is scanned independently by scanFormSpans, which finds
+// exact top-level form boundaries by tracking paren depth and skipping
+// comments the same way the grammar does; those spans are then matched
+// position-for-position with the Sexps the Parser returns. If the scan
+// and the Parser disagree on the number of top-level forms, the Sexps are
+// returned unwrapped, without positions.
+func ParseFile(fset *FileSet, name string, r io.Reader) ([]Sexp, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := fset.AddFile(name, len(src))
+	f.content = src
+
+	sexps, perr := Parse(bytes.NewReader(src))
+	if perr != nil {
+		pos := f.Position(len(src))
+		return nil, fmt.Errorf("%s: %w", pos, perr)
+	}
+
+	spans := scanFormSpans(src)
+	if len(spans) != len(sexps) {
+		// The independent scan and the Parser disagree on how many
+		// top-level forms there are (e.g. a Parser quirk not modeled
+		// by scanFormSpans); report the Sexps without positions rather
+		// than risk mismatched, misleading spans.
+		return sexps, nil
+	}
+
+	wrapped := make([]Sexp, len(sexps))
+	for i, s := range sexps {
+		wrapped[i] = &positioned{
+			Sexp:  s,
+			start: f.Position(spans[i].start),
+			end:   f.Position(spans[i].end),
+		}
+	}
+	return wrapped, nil
+}
+
+type formSpan struct{ start, end int }
+
+// scanFormSpans finds the exact [start, end) byte range of every
+// top-level form in src, the same way a Lisp reader would: skip
+// whitespace and comments between forms, then either consume a single
+// atom (a run of non-space, non-paren bytes) or a fully
+// paren-and-comment-aware balanced list.
+func scanFormSpans(src []byte) []formSpan {
+	var spans []formSpan
+	i, n := 0, len(src)
+	for {
+		i = skipGapBytes(src, i)
+		if i >= n {
+			return spans
+		}
+		start := i
+		i = scanOneForm(src, i)
+		spans = append(spans, formSpan{start, i})
+	}
+}
+
+// skipGapBytes advances past whitespace and comments.
+func skipGapBytes(src []byte, i int) int {
+	n := len(src)
+	for i < n {
+		switch {
+		case isSpaceByte(src[i]):
+			i++
+		case src[i] == ';':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+		case src[i] == '#' && i+1 < n && src[i+1] == '|':
+			i = skipBlockComment(src, i)
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func skipBlockComment(src []byte, i int) int {
+	n := len(src)
+	i += 2
+	for i+1 < n && !(src[i] == '|' && src[i+1] == '#') {
+		i++
+	}
+	if i+1 < n {
+		i += 2
+	} else {
+		i = n
+	}
+	return i
+}
+
+// scanOneForm consumes a single top-level form starting at i (already
+// known not to be whitespace or a comment) and returns the index just
+// past it.
+func scanOneForm(src []byte, i int) int {
+	n := len(src)
+	if src[i] != '(' {
+		for i < n && !isSpaceByte(src[i]) && src[i] != '(' && src[i] != ')' {
+			i++
+		}
+		return i
+	}
+
+	depth := 0
+	for i < n {
+		switch {
+		case src[i] == ';':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+		case src[i] == '#' && i+1 < n && src[i+1] == '|':
+			i = skipBlockComment(src, i)
+		case src[i] == '(':
+			depth++
+			i++
+		case src[i] == ')':
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
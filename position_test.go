@@ -0,0 +1,70 @@
+package sexp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilePosition(t *testing.T) {
+	fset := NewFileSet()
+	f := fset.AddFile("x.sexp", 0)
+	f.content = []byte("(f x)\n(g y)\n")
+
+	got := f.Position(7) // the 'g' in the second form
+	if got.Line != 2 || got.Column != 2 {
+		t.Fatalf("got %+v, want line 2 column 2", got)
+	}
+}
+
+func TestScanFormSpans(t *testing.T) {
+	src := []byte("(f x) ; trailing comment\n(g (h y))\n")
+	spans := scanFormSpans(src)
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d: %v", len(spans), spans)
+	}
+
+	if got, want := string(src[spans[0].start:spans[0].end]), "(f x)"; got != want {
+		t.Fatalf("span 0 = %q, want %q", got, want)
+	}
+	if got, want := string(src[spans[1].start:spans[1].end]), "(g (h y))"; got != want {
+		t.Fatalf("span 1 = %q, want %q", got, want)
+	}
+}
+
+func TestScanFormSpansSkipsBlockComments(t *testing.T) {
+	src := []byte("#| a (fake) form |# (f x)")
+	spans := scanFormSpans(src)
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d: %v", len(spans), spans)
+	}
+	if got, want := string(src[spans[0].start:spans[0].end]), "(f x)"; got != want {
+		t.Fatalf("span 0 = %q, want %q", got, want)
+	}
+}
+
+func TestParseFilePositions(t *testing.T) {
+	fset := NewFileSet()
+	sexps, err := ParseFile(fset, "x.sexp", strings.NewReader("(f x)\n(g y)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sexps) != 2 {
+		t.Fatalf("expected 2 sexps, got %d", len(sexps))
+	}
+
+	p0, ok := sexps[0].(Positioned)
+	if !ok {
+		t.Fatalf("expected sexps[0] to be Positioned, got %T", sexps[0])
+	}
+	if p0.Pos().Line != 1 {
+		t.Fatalf("expected sexps[0] to start on line 1, got %+v", p0.Pos())
+	}
+
+	p1, ok := sexps[1].(Positioned)
+	if !ok {
+		t.Fatalf("expected sexps[1] to be Positioned, got %T", sexps[1])
+	}
+	if p1.Pos().Line != 2 {
+		t.Fatalf("expected sexps[1] to start on line 2, got %+v", p1.Pos())
+	}
+}
@@ -0,0 +1,200 @@
+package sexp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mode is a set of flags (or 0) that controls printer behaviour.
+type Mode uint
+
+const (
+	UseTabs       Mode = 1 << iota // indent with tabs instead of spaces
+	AlignArgs                      // indent wrapped elements to the column reached after the head and any hanging arguments, instead of by Indent
+	DottedPairs                    // prefix the tail of a *Strict broken across lines with ". "; a *Strict that fits on one line prints as "(a b)"
+	CanonicalForm                  // ignore special-form hanging and always indent uniformly
+)
+
+// specialForm describes how many leading arguments of a special form hang
+// on the head line before the body is indented one step, mirroring how a
+// Lisp pretty-printer treats forms like defun or let differently from a
+// plain function call.
+type specialForm struct {
+	head    string
+	hanging int // number of arguments, after the head, kept on the head line
+}
+
+// specialForms is the built-in table of Lisp-idiomatic special forms. It
+// is not exhaustive; callers that need more can build their own Printer
+// and set Forms directly.
+var specialForms = map[string]specialForm{
+	"defun":  {"defun", 2}, // (defun name args body...)
+	"lambda": {"lambda", 1},
+	"let":    {"let", 1},
+	"let*":   {"let*", 1},
+	"if":     {"if", 1},
+	"cond":   {"cond", 0},
+}
+
+// Printer formats Sexp trees with configurable indentation and line
+// width, mirroring go/printer.Config. The zero value is not ready to
+// use; call NewPrinter or set Indent/MaxWidth explicitly.
+type Printer struct {
+	Indent   int  // number of columns (or tabs, if Mode&UseTabs) per indent level
+	MaxWidth int  // target maximum line width before breaking a form
+	Mode     Mode
+
+	// Forms maps a head Symbol to the number of its leading arguments
+	// that hang on the head line rather than being indented with the
+	// rest of the body. Defaults to specialForms if nil.
+	Forms map[string]int
+}
+
+// NewPrinter returns a Printer with sensible defaults: two-space indents,
+// an 80-column width, and the built-in special-form table.
+func NewPrinter() *Printer {
+	return &Printer{Indent: 2, MaxWidth: 80}
+}
+
+// Fprint formats node and writes it to w.
+func (p *Printer) Fprint(w io.Writer, node Sexp) error {
+	bw := &bufWriter{w: w}
+	p.print(bw, node, 0)
+	return bw.err
+}
+
+// bufWriter adapts an io.Writer so print can accumulate column position
+// without re-deriving it from the underlying writer on every call.
+type bufWriter struct {
+	w   io.Writer
+	col int
+	err error
+}
+
+func (b *bufWriter) write(s string) {
+	if b.err != nil {
+		return
+	}
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		b.col = len(s) - i - 1
+	} else {
+		b.col += len(s)
+	}
+	_, b.err = io.WriteString(b.w, s)
+}
+
+func (p *Printer) indentUnit() string {
+	if p.Mode&UseTabs != 0 {
+		return strings.Repeat("\t", p.Indent)
+	}
+	return strings.Repeat(" ", p.Indent)
+}
+
+// fits reports whether node renders on a single line of at most width
+// columns.
+func fits(node Sexp, width int) bool {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s", node)
+	s := buf.String()
+	return !strings.Contains(s, "\n") && len(s) <= width
+}
+
+func (p *Printer) print(w *bufWriter, node Sexp, depth int) {
+	// A position-tracked node (ParseFile's output) is transparent to
+	// printing, the same way Walk sees through it via Unwrap.
+	if u, ok := node.(interface{ Unwrap() Sexp }); ok {
+		p.print(w, u.Unwrap(), depth)
+		return
+	}
+
+	if strict, ok := node.(*Strict); ok {
+		p.printStrict(w, strict, depth)
+		return
+	}
+
+	list, ok := node.(List)
+	if !ok {
+		w.write(fmt.Sprintf("%s", node))
+		return
+	}
+
+	if len(list) == 0 {
+		w.write("()")
+		return
+	}
+
+	if p.Mode&CanonicalForm == 0 && fits(list, p.MaxWidth-w.col) {
+		w.write(fmt.Sprintf("%s", list))
+		return
+	}
+
+	w.write("(")
+
+	hanging := 0
+	if p.Mode&CanonicalForm == 0 {
+		if head, ok := list[0].(Symbol); ok {
+			if p.Forms != nil {
+				hanging = p.Forms[string(head)]
+			} else if form, ok := specialForms[string(head)]; ok {
+				hanging = form.hanging
+			}
+		}
+	}
+
+	// head (and any hanging arguments) stay on the first line.
+	p.print(w, list[0], depth)
+	lastHanging := 0
+	for i := 1; i <= hanging && i < len(list); i++ {
+		w.write(" ")
+		p.print(w, list[i], depth)
+		lastHanging = i
+	}
+
+	indent := strings.Repeat(p.indentUnit(), depth+1)
+	if p.Mode&AlignArgs != 0 {
+		indent = strings.Repeat(" ", w.col)
+	}
+
+	for i := lastHanging + 1; i < len(list); i++ {
+		w.write("\n")
+		w.write(indent)
+		p.print(w, list[i], depth+1)
+	}
+
+	w.write(")")
+}
+
+// printStrict prints a *Strict node, breaking across lines on the same
+// fits/MaxWidth basis as a List rather than always emitting it inline.
+func (p *Printer) printStrict(w *bufWriter, strict *Strict, depth int) {
+	if p.Mode&CanonicalForm == 0 && fits(strict, p.MaxWidth-w.col) {
+		w.write(fmt.Sprintf("%s", strict))
+		return
+	}
+
+	w.write("(")
+	p.print(w, strict.Sexp, depth+1)
+
+	if strict.child != nil {
+		indent := strings.Repeat(p.indentUnit(), depth+1)
+		if p.Mode&AlignArgs != 0 {
+			indent = strings.Repeat(" ", w.col)
+		}
+		w.write("\n")
+		w.write(indent)
+		if p.Mode&DottedPairs != 0 {
+			w.write(". ")
+		}
+		p.print(w, strict.child, depth+1)
+	}
+
+	w.write(")")
+}
+
+// Fprint formats node using a default Printer and writes it to w. It is a
+// convenience wrapper for one-off pretty-printing.
+func Fprint(w io.Writer, node Sexp) error {
+	return NewPrinter().Fprint(w, node)
+}
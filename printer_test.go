@@ -0,0 +1,107 @@
+package sexp
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestPrinterFitsOnOneLine(t *testing.T) {
+	root := List{Symbol("f"), Symbol("a"), Symbol("b")}
+	var buf bytes.Buffer
+	if err := (&Printer{Indent: 2, MaxWidth: 80}).Fprint(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "(f a b)"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrinterBreaksWhenTooWide(t *testing.T) {
+	root := List{Symbol("defun"), Symbol("factorial"), List{Symbol("x")},
+		List{Symbol("if"), List{Symbol("zerop"), Symbol("x")}, Symbol("1")}}
+
+	var buf bytes.Buffer
+	if err := (&Printer{Indent: 2, MaxWidth: 10}).Fprint(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !bytes.Contains([]byte(out), []byte("\n")) {
+		t.Fatalf("expected output to break across lines, got %q", out)
+	}
+}
+
+func TestPrinterBreaksLongStrictChain(t *testing.T) {
+	// Build a long *Strict chain: (a . (b . (c . (d . e))))
+	chain := &Strict{Sexp: Symbol("d"), child: Symbol("e")}
+	chain = &Strict{Sexp: Symbol("c"), child: chain}
+	chain = &Strict{Sexp: Symbol("b"), child: chain}
+	chain = &Strict{Sexp: Symbol("a"), child: chain}
+
+	var buf bytes.Buffer
+	if err := (&Printer{Indent: 2, MaxWidth: 5}).Fprint(&buf, chain); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !bytes.Contains([]byte(out), []byte("\n")) {
+		t.Fatalf("expected a long *Strict chain to break with MaxWidth 5, got %q", out)
+	}
+}
+
+func TestPrinterStrictFitsInline(t *testing.T) {
+	strict := &Strict{Sexp: Symbol("a"), child: Symbol("b")}
+	var buf bytes.Buffer
+	if err := (&Printer{Indent: 2, MaxWidth: 80}).Fprint(&buf, strict); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "(a b)"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPrinterRoundTrip(t *testing.T) {
+	for _, width := range []int{80, 10, 5} {
+		in := "(defun factorial (x) (if (zerop x) 1 (* x (factorial (- x 1)))))"
+
+		roots, err := ParseString(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := (&Printer{Indent: 2, MaxWidth: width}).Fprint(&buf, roots[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		reparsed, err := ParseString(buf.String())
+		if err != nil {
+			t.Fatalf("MaxWidth %d: failed to reparse printed output %q: %v", width, buf.String(), err)
+		}
+		if len(reparsed) != 1 {
+			t.Fatalf("MaxWidth %d: expected 1 form from reparse, got %d", width, len(reparsed))
+		}
+
+		got, want := fmt.Sprintf("%s", reparsed[0]), fmt.Sprintf("%s", roots[0])
+		if got != want {
+			t.Fatalf("MaxWidth %d: round-trip mismatch, got %q want %q", width, got, want)
+		}
+	}
+}
+
+// printerTestWrapper stands in for the *positioned type ParseFile
+// returns, without depending on position.go internals.
+type printerTestWrapper struct{ Sexp }
+
+func (w printerTestWrapper) Unwrap() Sexp { return w.Sexp }
+
+func TestPrinterUnwrapsPositionedNode(t *testing.T) {
+	root := printerTestWrapper{List{Symbol("f"), Symbol("a")}}
+
+	var buf bytes.Buffer
+	if err := (&Printer{Indent: 2, MaxWidth: 80}).Fprint(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "(f a)"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,282 @@
+// Package scope resolves lexical bindings introduced by common Lisp
+// binding forms (lambda, let, let*, letrec, defun, defvar), analogous to
+// go/ast.Scope, go/ast.Object, and the resolver driven by
+// go/ast.NewPackage.
+package scope
+
+import (
+	"fmt"
+	"strconv"
+
+	sexp "github.com/mibori/sexp"
+)
+
+// ObjKind describes what an Object represents.
+type ObjKind int
+
+const (
+	Bad ObjKind = iota // for error handling
+	Fun                // function, e.g. defun
+	Var                // variable bound by lambda/let/let*/letrec/defvar
+)
+
+func (kind ObjKind) String() string {
+	switch kind {
+	case Fun:
+		return "fun"
+	case Var:
+		return "var"
+	default:
+		return "bad"
+	}
+}
+
+// Object represents a named binding: a function or a variable.
+type Object struct {
+	Kind ObjKind
+	Name string
+	Decl sexp.Sexp   // the form that introduced the binding
+	Data interface{} // scope-specific information, e.g. the bound value form
+}
+
+// Scope maintains the set of named bindings visible at a point in the
+// tree, plus a link to the enclosing Scope.
+type Scope struct {
+	Outer   *Scope
+	Objects map[string]*Object
+
+	// Uses records, for the top-level Scope returned by Resolve, the
+	// binding resolved for each Symbol reference encountered. It is
+	// keyed by the address of the Sexp slot the Symbol occupies in its
+	// parent List, since Symbol is a value type and has no identity of
+	// its own.
+	Uses map[*sexp.Sexp]*Object
+}
+
+// NewScope creates a new Scope nested within outer. outer may be nil for
+// a universe (outermost) scope.
+func NewScope(outer *Scope) *Scope {
+	return &Scope{Outer: outer, Objects: map[string]*Object{}}
+}
+
+// Insert attempts to insert obj into s. If the scope already contains an
+// object with the same name, Insert leaves s unchanged and returns the
+// existing object; otherwise it inserts obj and returns nil.
+func (s *Scope) Insert(obj *Object) (alt *Object) {
+	if alt = s.Objects[obj.Name]; alt == nil {
+		s.Objects[obj.Name] = obj
+	}
+	return
+}
+
+// Lookup walks s and its outer scopes looking for name, returning the
+// first match or nil.
+func (s *Scope) Lookup(name string) *Object {
+	for sc := s; sc != nil; sc = sc.Outer {
+		if obj, ok := sc.Objects[name]; ok {
+			return obj
+		}
+	}
+	return nil
+}
+
+// BindingForm builds the Scope introduced by a binding-form List form
+// (the full form, including its head Symbol) nested within outer, and
+// reports the index of the first body element, i.e. the first element
+// of form that should be resolved in the new scope rather than treated
+// as part of the binding declaration itself.
+type BindingForm func(form sexp.List, outer *Scope) (inner *Scope, bodyStart int)
+
+// defaultForms is the built-in table of recognised binding forms.
+var defaultForms = map[string]BindingForm{
+	"lambda": bindLambda,
+	"let":    bindLet,
+	"let*":   bindLet,
+	"letrec": bindLet,
+	"defun":  bindDefun,
+	"defvar": bindDefvar,
+}
+
+// bindArgList inserts each Symbol in args (a flat list of parameter
+// names, or a List of them) as a Var into scope.
+func bindArgList(args sexp.Sexp, decl sexp.Sexp, scope *Scope) {
+	switch a := args.(type) {
+	case sexp.Symbol:
+		scope.Insert(&Object{Kind: Var, Name: string(a), Decl: decl})
+	case sexp.List:
+		for _, elt := range a {
+			bindArgList(elt, decl, scope)
+		}
+	}
+}
+
+// (lambda params body...)
+func bindLambda(form sexp.List, outer *Scope) (*Scope, int) {
+	inner := NewScope(outer)
+	if len(form) > 1 {
+		bindArgList(form[1], form, inner)
+	}
+	return inner, 2
+}
+
+// (let ((name val) ...) body...) and (let* ...) / (letrec ...)
+func bindLet(form sexp.List, outer *Scope) (*Scope, int) {
+	inner := NewScope(outer)
+	if len(form) > 1 {
+		if bindings, ok := form[1].(sexp.List); ok {
+			for _, b := range bindings {
+				switch bind := b.(type) {
+				case sexp.Symbol:
+					inner.Insert(&Object{Kind: Var, Name: string(bind), Decl: form})
+				case sexp.List:
+					if len(bind) > 0 {
+						if name, ok := bind[0].(sexp.Symbol); ok {
+							inner.Insert(&Object{Kind: Var, Name: string(name), Decl: form, Data: bind})
+						}
+					}
+				}
+			}
+		}
+	}
+	return inner, 2
+}
+
+// (defun name params body...)
+func bindDefun(form sexp.List, outer *Scope) (*Scope, int) {
+	if len(form) > 1 {
+		if name, ok := form[1].(sexp.Symbol); ok {
+			outer.Insert(&Object{Kind: Fun, Name: string(name), Decl: form})
+		}
+	}
+	inner := NewScope(outer)
+	if len(form) > 2 {
+		bindArgList(form[2], form, inner)
+	}
+	return inner, 3
+}
+
+// (defvar name val)
+func bindDefvar(form sexp.List, outer *Scope) (*Scope, int) {
+	if len(form) > 1 {
+		if name, ok := form[1].(sexp.Symbol); ok {
+			outer.Insert(&Object{Kind: Var, Name: string(name), Decl: form})
+		}
+	}
+	return outer, len(form)
+}
+
+// resolver carries the state threaded through a Resolve pass.
+type resolver struct {
+	forms  map[string]BindingForm
+	uses   map[*sexp.Sexp]*Object
+	errors []error
+}
+
+// RegisterBindingForm returns a copy of forms (of the built-in table, if
+// forms is nil) plus (or overriding with) name -> fn, letting callers
+// teach Resolve about binding forms from their own DSLs (e.g. a grammar
+// rule form that binds pattern variables) without losing the defaults.
+func RegisterBindingForm(forms map[string]BindingForm, name string, fn BindingForm) map[string]BindingForm {
+	if forms == nil {
+		forms = defaultForms
+	}
+	merged := map[string]BindingForm{}
+	for k, v := range forms {
+		merged[k] = v
+	}
+	merged[name] = fn
+	return merged
+}
+
+// Resolve walks root, opening a new Scope for each recognised binding
+// form and resolving every other Symbol against the innermost enclosing
+// Scope, starting from universe. It returns universe, whose Uses field
+// maps each resolved Symbol occurrence to its binding, and the list of
+// names that could not be resolved.
+//
+// Callers that need binding forms beyond lambda/let/let*/letrec/defun/
+// defvar should pass their own table, built with RegisterBindingForm,
+// via ResolveWithForms.
+func Resolve(root sexp.Sexp, universe *Scope) (*Scope, []error) {
+	return ResolveWithForms(root, universe, defaultForms)
+}
+
+// ResolveWithForms is Resolve with an explicit binding-form table.
+func ResolveWithForms(root sexp.Sexp, universe *Scope, forms map[string]BindingForm) (*Scope, []error) {
+	r := &resolver{forms: forms, uses: map[*sexp.Sexp]*Object{}}
+
+	// Wrap root in a single-element List so every node, including a
+	// bare top-level Symbol, occupies an addressable slot.
+	wrapper := sexp.List{root}
+	r.resolveSlot(&wrapper[0], universe)
+
+	universe.Uses = r.uses
+	return universe, r.errors
+}
+
+// isLiteral reports whether name is a self-evaluating literal (currently,
+// a number) rather than an identifier reference. The Sexp package has no
+// distinct numeric atom type, so a bare Symbol is the only place this
+// distinction can be made.
+func isLiteral(name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(name, 64)
+	return err == nil
+}
+
+func (r *resolver) resolveSlot(slot *sexp.Sexp, scope *Scope) {
+	// ParseFile wraps each top-level Sexp to attach source positions; see
+	// Walk, which sees through the same wrapper via Unwrap. Without this,
+	// resolving ParseFile's output would silently do nothing and report
+	// zero errors. Unwrapping in place, rather than recursing into a
+	// freshly allocated slot, keeps any resulting Uses entry keyed by the
+	// slot the caller actually owns and can look back up.
+	v := *slot
+	for {
+		u, ok := v.(interface{ Unwrap() sexp.Sexp })
+		if !ok {
+			break
+		}
+		v = u.Unwrap()
+	}
+
+	switch val := v.(type) {
+	case sexp.Symbol:
+		if isLiteral(string(val)) {
+			return
+		}
+		if obj := scope.Lookup(string(val)); obj != nil {
+			r.uses[slot] = obj
+		} else {
+			r.errors = append(r.errors, fmt.Errorf("unresolved symbol: %s", val))
+		}
+	case sexp.List:
+		r.resolveList(val, scope)
+	default:
+		// *sexp.Strict chains and Atom implementations other than Symbol
+		// are not descended into: Symbols inside them are neither bound
+		// nor reported.
+	}
+}
+
+func (r *resolver) resolveList(list sexp.List, scope *Scope) {
+	bodyStart := 0
+	inner := scope
+
+	if len(list) > 0 {
+		if head, ok := list[0].(sexp.Symbol); ok {
+			if bind, ok := r.forms[string(head)]; ok {
+				inner, bodyStart = bind(list, scope)
+			}
+		}
+	}
+
+	for i := range list {
+		if i < bodyStart {
+			continue
+		}
+		r.resolveSlot(&list[i], inner)
+	}
+}
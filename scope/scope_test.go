@@ -0,0 +1,132 @@
+package scope
+
+import (
+	"testing"
+
+	sexp "github.com/mibori/sexp"
+)
+
+func builtins() *Scope {
+	universe := NewScope(nil)
+	for _, name := range []string{"+", "-", "*", "zerop", "if"} {
+		universe.Insert(&Object{Kind: Fun, Name: name})
+	}
+	return universe
+}
+
+func TestResolveLambda(t *testing.T) {
+	root, err := sexp.ParseString("(lambda x (f x))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	universe := builtins()
+	universe.Insert(&Object{Kind: Fun, Name: "f"})
+
+	_, errs := Resolve(root[0], universe)
+	if len(errs) != 0 {
+		t.Fatalf("expected no unresolved symbols, got %v", errs)
+	}
+}
+
+func TestResolveUnbound(t *testing.T) {
+	root, err := sexp.ParseString("(lambda x (f y))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	universe := builtins()
+	universe.Insert(&Object{Kind: Fun, Name: "f"})
+
+	_, errs := Resolve(root[0], universe)
+	if len(errs) != 1 {
+		t.Fatalf("expected exactly one unresolved symbol, got %v", errs)
+	}
+}
+
+func TestResolveDefun(t *testing.T) {
+	root, err := sexp.ParseString("(defun factorial (x) (if (zerop x) 1 (* x (factorial (- x 1)))))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	universe := builtins()
+
+	scope, errs := Resolve(root[0], universe)
+	if len(errs) != 0 {
+		t.Fatalf("expected no unresolved symbols, got %v", errs)
+	}
+	if obj := scope.Lookup("factorial"); obj == nil || obj.Kind != Fun {
+		t.Fatalf("expected factorial to be bound as Fun, got %v", obj)
+	}
+}
+
+func TestRegisterBindingForm(t *testing.T) {
+	// (chartRule ROOT ((what wh) (is vbz) EXPR (? fullstop)) (Same))
+	root, err := sexp.ParseString("(chartRule ROOT ((what wh) (is vbz) EXPR (? fullstop)) (Same))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forms := RegisterBindingForm(defaultForms, "chartRule", func(form sexp.List, outer *Scope) (*Scope, int) {
+		inner := NewScope(outer)
+		if len(form) > 1 {
+			if name, ok := form[1].(sexp.Symbol); ok {
+				inner.Insert(&Object{Kind: Var, Name: string(name), Decl: form})
+			}
+		}
+		return inner, len(form)
+	})
+
+	universe := NewScope(nil)
+	if _, errs := ResolveWithForms(root[0], universe, forms); len(errs) != 0 {
+		t.Fatalf("expected no unresolved symbols, got %v", errs)
+	}
+}
+
+// positionWrapper stands in for the *positioned type ParseFile returns,
+// without depending on sexp package internals.
+type positionWrapper struct {
+	sexp.Sexp
+}
+
+func (p positionWrapper) Unwrap() sexp.Sexp { return p.Sexp }
+
+func TestResolveSeesThroughPositionWrapper(t *testing.T) {
+	// (lambda x (f x)), as ParseFile would hand it back: wrapped.
+	inner := sexp.List{sexp.Symbol("lambda"), sexp.Symbol("x"), sexp.List{sexp.Symbol("f"), sexp.Symbol("x")}}
+	root := positionWrapper{inner}
+
+	universe := NewScope(nil)
+	universe.Insert(&Object{Kind: Fun, Name: "f"})
+
+	_, errs := Resolve(root, universe)
+	if len(errs) != 0 {
+		t.Fatalf("expected no unresolved symbols, got %v", errs)
+	}
+}
+
+func TestResolveUsesKeyedByCallerOwnedSlot(t *testing.T) {
+	// (f x): the caller owns both element slots directly, so any Uses
+	// entry must be keyed by one of &body[0] or &body[1], not by some
+	// address the caller has no way to reproduce.
+	body := sexp.List{sexp.Symbol("f"), sexp.Symbol("x")}
+
+	universe := NewScope(nil)
+	universe.Insert(&Object{Kind: Fun, Name: "f"})
+	universe.Insert(&Object{Kind: Var, Name: "x"})
+
+	scope, errs := Resolve(body, universe)
+	if len(errs) != 0 {
+		t.Fatalf("expected no unresolved symbols, got %v", errs)
+	}
+	if len(scope.Uses) != 2 {
+		t.Fatalf("expected 2 Uses entries, got %d", len(scope.Uses))
+	}
+	if obj, ok := scope.Uses[&body[0]]; !ok || obj.Name != "f" {
+		t.Fatalf("expected Uses[&body[0]] to resolve to f, got %v, %v", obj, ok)
+	}
+	if obj, ok := scope.Uses[&body[1]]; !ok || obj.Name != "x" {
+		t.Fatalf("expected Uses[&body[1]] to resolve to x, got %v, %v", obj, ok)
+	}
+}
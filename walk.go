@@ -0,0 +1,62 @@
+package sexp
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each child of node
+// with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Sexp) (w Visitor)
+}
+
+// Walk traverses a Sexp tree in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor w for
+// each of the children of node, followed by a call of w.Visit(nil).
+//
+// Walk is modeled on go/ast.Walk: a List's children are walked in order,
+// a *Strict's head and then its tail, and an Atom (such as Symbol, or any
+// custom type implementing Cloner) is treated as a leaf. Any other node
+// with an Unwrap() Sexp method is walked through to the Sexp it wraps.
+func Walk(v Visitor, node Sexp) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case List:
+		for _, child := range n {
+			Walk(v, child)
+		}
+	case *Strict:
+		Walk(v, n.Sexp)
+		if n.child != nil {
+			Walk(v, n.child)
+		}
+	case Atom:
+		// leaf node, nothing further to walk
+	default:
+		if u, ok := node.(interface{ Unwrap() Sexp }); ok {
+			Walk(v, u.Unwrap())
+		}
+		// otherwise: unknown Sexp implementation; treat as a leaf
+	}
+
+	v.Visit(nil)
+}
+
+// inspector is a Visitor adapter that calls a function for every node.
+type inspector func(Sexp) bool
+
+func (f inspector) Visit(node Sexp) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a Sexp tree in depth-first order: it starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the children of node, followed by a call of
+// f(nil).
+func Inspect(node Sexp, f func(Sexp) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,80 @@
+package sexp
+
+import "testing"
+
+func TestInspectVisitsSymbolsInOrder(t *testing.T) {
+	root := List{Symbol("f"), Symbol("a"), List{Symbol("g"), Symbol("b")}}
+
+	var got []string
+	Inspect(root, func(s Sexp) bool {
+		if s == nil {
+			return false
+		}
+		if sym, ok := s.(Symbol); ok {
+			got = append(got, string(sym))
+		}
+		return true
+	})
+
+	want := []string{"f", "a", "g", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWalkEmitsEndOfSubtreeMarker(t *testing.T) {
+	root := List{Symbol("f"), Symbol("a")}
+
+	var events []string
+	v := visitorFunc(func(node Sexp) Visitor {
+		if node == nil {
+			events = append(events, "end")
+			return nil
+		}
+		events = append(events, "visit")
+		return visitorFunc(func(n Sexp) Visitor {
+			if n == nil {
+				events = append(events, "end")
+				return nil
+			}
+			events = append(events, "visit")
+			return nil
+		})
+	})
+	Walk(v, root)
+
+	if len(events) == 0 || events[0] != "visit" || events[len(events)-1] != "end" {
+		t.Fatalf("expected a visit/.../end sequence, got %v", events)
+	}
+}
+
+type visitorFunc func(Sexp) Visitor
+
+func (f visitorFunc) Visit(node Sexp) Visitor { return f(node) }
+
+func TestWalkSeesThroughUnwrap(t *testing.T) {
+	inner := List{Symbol("f"), Symbol("a")}
+	w := walkTestWrapper{inner}
+	var seen []string
+	Inspect(w, func(s Sexp) bool {
+		if s == nil {
+			return false
+		}
+		if sym, ok := s.(Symbol); ok {
+			seen = append(seen, string(sym))
+		}
+		return true
+	})
+	if len(seen) != 2 {
+		t.Fatalf("expected Walk to see through the wrapper into 2 symbols, got %v", seen)
+	}
+}
+
+type walkTestWrapper struct{ Sexp }
+
+func (w walkTestWrapper) Unwrap() Sexp { return w.Sexp }